@@ -0,0 +1,192 @@
+package packet
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// ICMPMessage is one captured ICMP message.
+//
+// Only ICMPv4 is decoded for now: ICMPv6 info messages (echo,
+// destination-unreachable, packet-too-big) are split across several
+// chained gopacket layers rather than one struct, and that decode
+// path hasn't been added yet.
+type ICMPMessage struct {
+	ip         *IPPacket
+	Type       uint8
+	Code       uint8
+	Identifier uint16
+	Sequence   uint16
+	payload    []byte
+	timestamp  time.Time
+}
+
+// IP returns the L3 envelope the message arrived in.
+func (m *ICMPMessage) IP() *IPPacket { return m.ip }
+
+// Protocol always returns ProtocolICMP for an ICMPMessage.
+func (m *ICMPMessage) Protocol() Protocol { return ProtocolICMP }
+
+// Data returns the ICMP message body (for echo, the echoed payload;
+// for error messages, the offending IP header + leading bytes).
+func (m *ICMPMessage) Data() []byte { return m.payload }
+
+// CapturedAt returns when the message was captured.
+func (m *ICMPMessage) CapturedAt() time.Time { return m.timestamp }
+
+// IsEchoRequest reports whether m is an echo request (ping).
+func (m *ICMPMessage) IsEchoRequest() bool {
+	return m.Type == uint8(layers.ICMPv4TypeEchoRequest)
+}
+
+// IsEchoReply reports whether m is an echo reply (pong).
+func (m *ICMPMessage) IsEchoReply() bool {
+	return m.Type == uint8(layers.ICMPv4TypeEchoReply)
+}
+
+// IsFragmentationNeeded reports whether m is a "destination
+// unreachable, fragmentation needed" message (path MTU discovery).
+func (m *ICMPMessage) IsFragmentationNeeded() bool {
+	return m.Type == uint8(layers.ICMPv4TypeDestinationUnreachable) &&
+		m.Code == uint8(layers.ICMPv4CodeFragmentationNeeded)
+}
+
+// IsUnreachable reports whether m is any destination-unreachable
+// message, including fragmentation-needed.
+func (m *ICMPMessage) IsUnreachable() bool {
+	return m.Type == uint8(layers.ICMPv4TypeDestinationUnreachable)
+}
+
+// echoKey identifies one echo request/reply exchange. It's direction
+// independent so a reply (with src/dst swapped from its request)
+// matches the request it answers.
+type echoKey struct {
+	endpointA, endpointB string
+	identifier, sequence uint16
+}
+
+func newEchoKey(m *ICMPMessage) echoKey {
+	a, b := m.ip.Src.String(), m.ip.Dst.String()
+	if a > b {
+		a, b = b, a
+	}
+	return echoKey{endpointA: a, endpointB: b, identifier: m.Identifier, sequence: m.Sequence}
+}
+
+// parseICMP decodes pkt as an ICMPv4 message.
+func parseICMP(pkt gopacket.Packet) (L4, error) {
+	icmp, ok := pkt.Layer(layers.LayerTypeICMPv4).(*layers.ICMPv4)
+	if !ok {
+		return nil, fmt.Errorf("packet: no ICMPv4 layer")
+	}
+	timestamp := pkt.Metadata().Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	return &ICMPMessage{
+		ip:         ipEnvelope(pkt, ProtocolICMP),
+		Type:       uint8(icmp.TypeCode.Type()),
+		Code:       uint8(icmp.TypeCode.Code()),
+		Identifier: icmp.Id,
+		Sequence:   icmp.Seq,
+		payload:    icmp.Payload,
+		timestamp:  timestamp,
+	}, nil
+}
+
+// ICMPEventKind identifies the kind of out-of-band event an
+// ICMPDecoder surfaces.
+type ICMPEventKind uint8
+
+// ICMP event kinds surfaced on an ICMPDecoder's Events channel.
+const (
+	ICMPEventUnreachable ICMPEventKind = iota
+	ICMPEventFragmentationNeeded
+)
+
+// ICMPEvent reports an unreachable/fragmentation-needed message seen
+// mid-capture, so the capture engine can log path-MTU problems.
+type ICMPEvent struct {
+	Kind      ICMPEventKind
+	IP        *IPPacket
+	Timestamp time.Time
+}
+
+// EchoRTT reports the round-trip time measured between an echo
+// request and its matching reply.
+type EchoRTT struct {
+	Src, Dst   string
+	Identifier uint16
+	Sequence   uint16
+	RTT        time.Duration
+}
+
+// ICMPDecoder pairs echo request/reply messages to compute RTTs and
+// surfaces destination-unreachable/fragmentation-needed messages on a
+// side channel. It's safe for concurrent use.
+type ICMPDecoder struct {
+	mu      sync.Mutex
+	pending map[echoKey]*ICMPMessage
+
+	events chan ICMPEvent
+}
+
+// NewICMPDecoder returns an ICMPDecoder whose Events channel buffers up
+// to eventBuf events; once full, further events are dropped rather than
+// blocking capture.
+func NewICMPDecoder(eventBuf int) *ICMPDecoder {
+	return &ICMPDecoder{
+		pending: make(map[echoKey]*ICMPMessage),
+		events:  make(chan ICMPEvent, eventBuf),
+	}
+}
+
+// Events returns the side channel of unreachable/fragmentation-needed
+// events. Callers should keep draining it.
+func (d *ICMPDecoder) Events() <-chan ICMPEvent { return d.events }
+
+// Observe feeds one ICMPMessage through the decoder. If msg completes
+// an echo request/reply pair, it returns the measured RTT and ok=true.
+func (d *ICMPDecoder) Observe(msg *ICMPMessage) (rtt EchoRTT, ok bool) {
+	switch {
+	case msg.IsEchoRequest():
+		d.mu.Lock()
+		d.pending[newEchoKey(msg)] = msg
+		d.mu.Unlock()
+
+	case msg.IsEchoReply():
+		key := newEchoKey(msg)
+		d.mu.Lock()
+		req, found := d.pending[key]
+		if found {
+			delete(d.pending, key)
+		}
+		d.mu.Unlock()
+		if !found {
+			return EchoRTT{}, false
+		}
+		return EchoRTT{
+			Src:        msg.ip.Src.String(),
+			Dst:        msg.ip.Dst.String(),
+			Identifier: msg.Identifier,
+			Sequence:   msg.Sequence,
+			RTT:        msg.timestamp.Sub(req.timestamp),
+		}, true
+
+	case msg.IsUnreachable():
+		kind := ICMPEventUnreachable
+		if msg.IsFragmentationNeeded() {
+			kind = ICMPEventFragmentationNeeded
+		}
+		select {
+		case d.events <- ICMPEvent{Kind: kind, IP: msg.ip, Timestamp: msg.timestamp}:
+		default:
+			// buffer full: drop rather than block capture
+		}
+	}
+	return EchoRTT{}, false
+}
@@ -0,0 +1,67 @@
+package packet
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket/layers"
+)
+
+func echoMsg(src, dst string, id, seq uint16, reply bool, ts time.Time) *ICMPMessage {
+	typ := uint8(layers.ICMPv4TypeEchoRequest)
+	if reply {
+		typ = uint8(layers.ICMPv4TypeEchoReply)
+	}
+	return &ICMPMessage{
+		ip:         &IPPacket{Src: net.ParseIP(src), Dst: net.ParseIP(dst), Protocol: ProtocolICMP},
+		Type:       typ,
+		Identifier: id,
+		Sequence:   seq,
+		timestamp:  ts,
+	}
+}
+
+func TestICMPDecoder_MatchesEchoRequestReply(t *testing.T) {
+	d := NewICMPDecoder(4)
+	start := time.Unix(1000, 0)
+
+	if _, ok := d.Observe(echoMsg("10.0.0.1", "10.0.0.2", 7, 1, false, start)); ok {
+		t.Fatalf("echo request alone shouldn't produce an RTT")
+	}
+
+	rtt, ok := d.Observe(echoMsg("10.0.0.2", "10.0.0.1", 7, 1, true, start.Add(20*time.Millisecond)))
+	if !ok {
+		t.Fatalf("expected the reply to match the pending request")
+	}
+	if rtt.RTT != 20*time.Millisecond {
+		t.Fatalf("RTT = %s, want 20ms", rtt.RTT)
+	}
+}
+
+func TestICMPDecoder_UnmatchedReplyIgnored(t *testing.T) {
+	d := NewICMPDecoder(4)
+	if _, ok := d.Observe(echoMsg("10.0.0.2", "10.0.0.1", 9, 1, true, time.Now())); ok {
+		t.Fatalf("a reply with no pending request shouldn't produce an RTT")
+	}
+}
+
+func TestICMPDecoder_FragmentationNeededEvent(t *testing.T) {
+	d := NewICMPDecoder(4)
+	msg := &ICMPMessage{
+		ip:        &IPPacket{Src: net.ParseIP("10.0.0.1"), Dst: net.ParseIP("10.0.0.2"), Protocol: ProtocolICMP},
+		Type:      uint8(layers.ICMPv4TypeDestinationUnreachable),
+		Code:      uint8(layers.ICMPv4CodeFragmentationNeeded),
+		timestamp: time.Now(),
+	}
+	d.Observe(msg)
+
+	select {
+	case ev := <-d.Events():
+		if ev.Kind != ICMPEventFragmentationNeeded {
+			t.Fatalf("Kind = %v, want ICMPEventFragmentationNeeded", ev.Kind)
+		}
+	default:
+		t.Fatalf("expected a fragmentation-needed event to be emitted")
+	}
+}
@@ -0,0 +1,76 @@
+// Package packet provides protocol-agnostic capture types so the
+// pipeline isn't hard-wired to TCP: an L3 IPPacket envelope plus
+// per-protocol L4 implementations (TCPSegment, UDPDatagram,
+// ICMPMessage), modeled on cloudflared's L3/L4 split.
+//
+// The capture pipeline (not present in this tree) is expected to pick
+// which L4 protocols to emit via an --input-raw-protocols=tcp,udp,icmp
+// flag and call ParseL4 per packet.
+package packet
+
+import (
+	"net"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// Protocol identifies which L4 protocol a packet carries.
+type Protocol uint8
+
+// Supported L4 protocols.
+const (
+	ProtocolTCP Protocol = iota
+	ProtocolUDP
+	ProtocolICMP
+)
+
+// String returns the lower-case protocol name: "tcp", "udp" or "icmp"
+// ("unknown" for any other value).
+func (p Protocol) String() string {
+	switch p {
+	case ProtocolTCP:
+		return "tcp"
+	case ProtocolUDP:
+		return "udp"
+	case ProtocolICMP:
+		return "icmp"
+	default:
+		return "unknown"
+	}
+}
+
+// IPPacket is the protocol-agnostic L3 envelope shared by every L4
+// packet type in this package.
+type IPPacket struct {
+	Src, Dst net.IP
+	TTL      uint8
+	Protocol Protocol
+}
+
+// L4 is implemented by every transport-layer packet this package
+// understands: TCPSegment, UDPDatagram, and ICMPMessage.
+type L4 interface {
+	// IP returns the L3 envelope the L4 payload arrived in.
+	IP() *IPPacket
+	// Protocol identifies which concrete type this is.
+	Protocol() Protocol
+	// Data returns the L4 payload (application data).
+	Data() []byte
+	// CapturedAt returns when the packet was captured.
+	CapturedAt() time.Time
+}
+
+// ipEnvelope builds the L3 IPPacket for pkt, given the resolved L4
+// protocol. It supports both IPv4 and IPv6 network layers.
+func ipEnvelope(pkt gopacket.Packet, proto Protocol) *IPPacket {
+	switch net := pkt.NetworkLayer().(type) {
+	case *layers.IPv4:
+		return &IPPacket{Src: net.SrcIP, Dst: net.DstIP, TTL: net.TTL, Protocol: proto}
+	case *layers.IPv6:
+		return &IPPacket{Src: net.SrcIP, Dst: net.DstIP, TTL: net.HopLimit, Protocol: proto}
+	default:
+		return &IPPacket{Protocol: proto}
+	}
+}
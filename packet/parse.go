@@ -0,0 +1,27 @@
+package packet
+
+import (
+	"fmt"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// ErrUnsupportedProtocol is returned by ParseL4 when pkt carries none
+// of the L4 protocols this package understands.
+var ErrUnsupportedProtocol = fmt.Errorf("packet: unsupported L4 protocol")
+
+// ParseL4 decodes pkt's transport layer into the matching L4
+// implementation: TCPSegment, UDPDatagram, or ICMPMessage.
+func ParseL4(pkt gopacket.Packet) (L4, error) {
+	if pkt.Layer(layers.LayerTypeTCP) != nil {
+		return parseTCP(pkt)
+	}
+	if pkt.Layer(layers.LayerTypeUDP) != nil {
+		return parseUDP(pkt)
+	}
+	if pkt.Layer(layers.LayerTypeICMPv4) != nil {
+		return parseICMP(pkt)
+	}
+	return nil, ErrUnsupportedProtocol
+}
@@ -0,0 +1,36 @@
+package packet
+
+import (
+	"time"
+
+	"github.com/google/gopacket"
+
+	"github.com/OkCustomer/goreplay/tcp"
+)
+
+// TCPSegment adapts a *tcp.Packet to the L4 interface.
+type TCPSegment struct {
+	*tcp.Packet
+	ip *IPPacket
+}
+
+// IP returns the L3 envelope the segment arrived in.
+func (s *TCPSegment) IP() *IPPacket { return s.ip }
+
+// Protocol always returns ProtocolTCP for a TCPSegment.
+func (s *TCPSegment) Protocol() Protocol { return ProtocolTCP }
+
+// Data returns the TCP payload.
+func (s *TCPSegment) Data() []byte { return s.Packet.TCP.LayerPayload() }
+
+// CapturedAt returns when the segment was captured.
+func (s *TCPSegment) CapturedAt() time.Time { return s.Packet.Timestamp }
+
+// parseTCP decodes pkt as a TCP segment via tcp.ParsePacket.
+func parseTCP(pkt gopacket.Packet) (L4, error) {
+	p, err := tcp.ParsePacket(pkt)
+	if err != nil {
+		return nil, err
+	}
+	return &TCPSegment{Packet: p, ip: ipEnvelope(pkt, ProtocolTCP)}, nil
+}
@@ -0,0 +1,52 @@
+package packet
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+func TestParseL4_TCPSetsIPEnvelope(t *testing.T) {
+	eth := &layers.Ethernet{
+		SrcMAC:       net.HardwareAddr{0, 0, 0, 0, 0, 1},
+		DstMAC:       net.HardwareAddr{0, 0, 0, 0, 0, 2},
+		EthernetType: layers.EthernetTypeIPv4,
+	}
+	ip := &layers.IPv4{
+		Version:  4,
+		IHL:      5,
+		TTL:      53,
+		Protocol: layers.IPProtocolTCP,
+		SrcIP:    net.IPv4(10, 0, 0, 1),
+		DstIP:    net.IPv4(10, 0, 0, 2),
+	}
+	tcpHdr := &layers.TCP{SrcPort: 40000, DstPort: 80, Seq: 1, Window: 1024}
+	if err := tcpHdr.SetNetworkLayerForChecksum(ip); err != nil {
+		t.Fatalf("SetNetworkLayerForChecksum: %v", err)
+	}
+
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if err := gopacket.SerializeLayers(buf, opts, eth, ip, tcpHdr); err != nil {
+		t.Fatalf("SerializeLayers: %v", err)
+	}
+
+	raw := gopacket.NewPacket(buf.Bytes(), layers.LayerTypeEthernet, gopacket.Default)
+	l4, err := ParseL4(raw)
+	if err != nil {
+		t.Fatalf("ParseL4: %v", err)
+	}
+
+	envelope := l4.IP()
+	if envelope.TTL != 53 {
+		t.Fatalf("TTL = %d, want 53", envelope.TTL)
+	}
+	if envelope.Protocol != ProtocolTCP {
+		t.Fatalf("Protocol = %v, want ProtocolTCP", envelope.Protocol)
+	}
+	if !envelope.Src.Equal(net.IPv4(10, 0, 0, 1)) || !envelope.Dst.Equal(net.IPv4(10, 0, 0, 2)) {
+		t.Fatalf("unexpected Src/Dst: %+v", envelope)
+	}
+}
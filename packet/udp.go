@@ -0,0 +1,136 @@
+package packet
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// UDPDatagram is one captured UDP datagram.
+type UDPDatagram struct {
+	ip        *IPPacket
+	SrcPort   layers.UDPPort
+	DstPort   layers.UDPPort
+	payload   []byte
+	timestamp time.Time
+}
+
+// IP returns the L3 envelope the datagram arrived in.
+func (d *UDPDatagram) IP() *IPPacket { return d.ip }
+
+// Protocol always returns ProtocolUDP for a UDPDatagram.
+func (d *UDPDatagram) Protocol() Protocol { return ProtocolUDP }
+
+// Data returns the UDP payload.
+func (d *UDPDatagram) Data() []byte { return d.payload }
+
+// CapturedAt returns when the datagram was captured.
+func (d *UDPDatagram) CapturedAt() time.Time { return d.timestamp }
+
+// parseUDP decodes pkt as a UDP datagram.
+func parseUDP(pkt gopacket.Packet) (L4, error) {
+	udp, ok := pkt.TransportLayer().(*layers.UDP)
+	if !ok {
+		return nil, fmt.Errorf("packet: no UDP transport layer")
+	}
+	timestamp := pkt.Metadata().Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	return &UDPDatagram{
+		ip:        ipEnvelope(pkt, ProtocolUDP),
+		SrcPort:   udp.SrcPort,
+		DstPort:   udp.DstPort,
+		payload:   udp.Payload,
+		timestamp: timestamp,
+	}, nil
+}
+
+// UDPFlowKey identifies a UDP flow by its 5-tuple. It's direction
+// independent so a reply (with src/dst address and port swapped from
+// its request) lands in the same flow.
+type UDPFlowKey struct {
+	EndpointA, EndpointB string
+	PortA, PortB         layers.UDPPort
+}
+
+func udpFlowKey(d *UDPDatagram) UDPFlowKey {
+	a, b := d.ip.Src.String(), d.ip.Dst.String()
+	pa, pb := d.SrcPort, d.DstPort
+	if a > b || (a == b && pa > pb) {
+		a, b = b, a
+		pa, pb = pb, pa
+	}
+	return UDPFlowKey{EndpointA: a, EndpointB: b, PortA: pa, PortB: pb}
+}
+
+// UDPFlow groups the UDP datagrams seen for one 5-tuple, in arrival
+// order, e.g. for reconstructing a DNS exchange or a QUIC handshake.
+type UDPFlow struct {
+	Key       UDPFlowKey
+	Datagrams []*UDPDatagram
+
+	last time.Time
+}
+
+// UDPFlowAssembler groups UDP datagrams into UDPFlows by 5-tuple,
+// evicting flows that have been idle past idleTimeout. It's safe for
+// concurrent use.
+type UDPFlowAssembler struct {
+	mu          sync.Mutex
+	flows       map[UDPFlowKey]*UDPFlow
+	idleTimeout time.Duration
+}
+
+// NewUDPFlowAssembler returns a UDPFlowAssembler that evicts flows idle
+// for longer than idleTimeout.
+func NewUDPFlowAssembler(idleTimeout time.Duration) *UDPFlowAssembler {
+	return &UDPFlowAssembler{
+		flows:       make(map[UDPFlowKey]*UDPFlow),
+		idleTimeout: idleTimeout,
+	}
+}
+
+// Add appends dgram to its flow (creating one if this is the first
+// datagram seen for the 5-tuple) and returns it.
+func (a *UDPFlowAssembler) Add(dgram *UDPDatagram) *UDPFlow {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.evictIdleLocked()
+
+	key := udpFlowKey(dgram)
+	f := a.flows[key]
+	if f == nil {
+		f = &UDPFlow{Key: key}
+		a.flows[key] = f
+	}
+	f.Datagrams = append(f.Datagrams, dgram)
+	f.last = dgram.timestamp
+
+	return f
+}
+
+// evictIdleLocked drops flows that haven't seen a datagram within
+// idleTimeout. Callers must hold a.mu.
+func (a *UDPFlowAssembler) evictIdleLocked() {
+	if len(a.flows) == 0 {
+		return
+	}
+	cutoff := time.Now().Add(-a.idleTimeout)
+	for k, f := range a.flows {
+		if f.last.Before(cutoff) {
+			delete(a.flows, k)
+		}
+	}
+}
+
+// Len returns the number of flows currently tracked.
+func (a *UDPFlowAssembler) Len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.flows)
+}
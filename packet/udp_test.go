@@ -0,0 +1,68 @@
+package packet
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket/layers"
+)
+
+func newUDPDatagram(src, dst string, srcPort, dstPort layers.UDPPort, ts time.Time) *UDPDatagram {
+	return &UDPDatagram{
+		ip:        &IPPacket{Src: net.ParseIP(src), Dst: net.ParseIP(dst), Protocol: ProtocolUDP},
+		SrcPort:   srcPort,
+		DstPort:   dstPort,
+		timestamp: ts,
+	}
+}
+
+func TestUDPFlowAssembler_GroupsBy5Tuple(t *testing.T) {
+	a := NewUDPFlowAssembler(time.Minute)
+	now := time.Now()
+
+	f1 := a.Add(newUDPDatagram("10.0.0.1", "10.0.0.2", 5353, 53, now))
+	f2 := a.Add(newUDPDatagram("10.0.0.1", "10.0.0.2", 5353, 53, now.Add(time.Second)))
+	f3 := a.Add(newUDPDatagram("10.0.0.1", "10.0.0.2", 5354, 53, now))
+
+	if f1 != f2 {
+		t.Fatalf("expected datagrams on the same 5-tuple to share a flow")
+	}
+	if f1 == f3 {
+		t.Fatalf("expected datagrams with a different source port to land in separate flows")
+	}
+	if len(f1.Datagrams) != 2 {
+		t.Fatalf("flow has %d datagrams, want 2", len(f1.Datagrams))
+	}
+}
+
+func TestUDPFlowAssembler_GroupsRequestAndReplyTogether(t *testing.T) {
+	a := NewUDPFlowAssembler(time.Minute)
+	now := time.Now()
+
+	req := a.Add(newUDPDatagram("10.0.0.1", "10.0.0.2", 5353, 53, now))
+	reply := a.Add(newUDPDatagram("10.0.0.2", "10.0.0.1", 53, 5353, now.Add(time.Millisecond)))
+
+	if req != reply {
+		t.Fatalf("expected a request and its reply to share a flow")
+	}
+	if len(req.Datagrams) != 2 {
+		t.Fatalf("flow has %d datagrams, want 2", len(req.Datagrams))
+	}
+}
+
+func TestUDPFlowAssembler_EvictsIdleFlows(t *testing.T) {
+	a := NewUDPFlowAssembler(time.Second)
+
+	old := time.Unix(1000, 0)
+	a.Add(newUDPDatagram("10.0.0.1", "10.0.0.2", 1, 2, old))
+
+	// This datagram arrives on a different flow, far enough in wall
+	// clock time that the first flow should be evicted as a side
+	// effect of the Add call (eviction uses time.Now, not dgram time).
+	a.Add(newUDPDatagram("10.0.0.3", "10.0.0.4", 3, 4, time.Now()))
+
+	if got := a.Len(); got != 1 {
+		t.Fatalf("Len() = %d, want 1 after idle flow eviction", got)
+	}
+}
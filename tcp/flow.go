@@ -0,0 +1,52 @@
+package tcp
+
+import (
+	"bytes"
+	"hash/fnv"
+
+	"github.com/google/gopacket"
+)
+
+// NetworkFlow returns the IP-layer flow (source/destination address
+// pair) for the packet.
+func (pckt *Packet) NetworkFlow() gopacket.Flow {
+	return pckt.NetworkLayer.NetworkFlow()
+}
+
+// TransportFlow returns the TCP-layer flow (source/destination port
+// pair) for the packet.
+func (pckt *Packet) TransportFlow() gopacket.Flow {
+	return pckt.TCP.TransportFlow()
+}
+
+// flowKeyBytes concatenates an endpoint's raw bytes with its transport
+// endpoint's raw bytes into a fresh slice suitable for hashing.
+func flowKeyBytes(network, transport gopacket.Endpoint) []byte {
+	b := make([]byte, 0, len(network.Raw())+len(transport.Raw()))
+	b = append(b, network.Raw()...)
+	b = append(b, transport.Raw()...)
+	return b
+}
+
+// FlowKey returns a direction-independent identifier for the
+// connection this packet belongs to: the same value is returned for
+// packets seen in either direction of a TCP connection, so it can be
+// used as a map key for per-connection state without callers having to
+// normalize endpoint order themselves.
+func (pckt *Packet) FlowKey() uint64 {
+	nsrc, ndst := pckt.NetworkFlow().Endpoints()
+	tsrc, tdst := pckt.TransportFlow().Endpoints()
+
+	src := flowKeyBytes(nsrc, tsrc)
+	dst := flowKeyBytes(ndst, tdst)
+
+	h := fnv.New64a()
+	if bytes.Compare(src, dst) <= 0 {
+		h.Write(src)
+		h.Write(dst)
+	} else {
+		h.Write(dst)
+		h.Write(src)
+	}
+	return h.Sum64()
+}
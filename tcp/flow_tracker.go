@@ -0,0 +1,167 @@
+package tcp
+
+import (
+	"bytes"
+	"sync"
+	"time"
+)
+
+// flowShardCount is the number of independent shards FlowTracker
+// spreads flows across, so unrelated flows don't contend on the same
+// mutex under heavy replay traffic.
+const flowShardCount = 32
+
+// FlowCounters are the per-flow counters exposed by FlowTracker.
+type FlowCounters struct {
+	Bytes       uint64
+	Segments    uint64
+	Retransmits uint64 // segments whose Seq regressed behind what was already seen
+	RSTs        uint64
+	OutOfOrder  uint64 // segments that arrived ahead of the expected Seq
+
+	// RTT is the time between this flow's SYN and SYN-ACK, zero until
+	// both have been observed.
+	RTT time.Duration
+}
+
+// dirState is the sequence-number bookkeeping for one direction of a
+// connection.
+type dirState struct {
+	haveSeq bool
+	nextSeq uint32
+}
+
+// flowState is the mutable per-flow bookkeeping backing FlowCounters.
+// fwd/rev track sequence numbers separately per direction, with refSrc
+// (the first packet's source) deciding which is which.
+type flowState struct {
+	FlowCounters
+
+	haveRef bool
+	refSrc  []byte
+	fwd     dirState
+	rev     dirState
+
+	synAt    time.Time
+	synAckAt time.Time
+}
+
+// seqBefore reports whether a is before b in TCP sequence-number space,
+// accounting for wraparound.
+func seqBefore(a, b uint32) bool {
+	return int32(a-b) < 0
+}
+
+// flowShard is one lock-protected bucket of flows.
+type flowShard struct {
+	mu    sync.Mutex
+	flows map[uint64]*flowState
+}
+
+// FlowSnapshot pairs a flow's key with a point-in-time copy of its
+// counters, as returned by FlowTracker.Snapshot.
+type FlowSnapshot struct {
+	Key      uint64
+	Counters FlowCounters
+}
+
+// FlowTracker maintains per-flow traffic counters (bytes, segments,
+// retransmits, RSTs, out-of-order segments, handshake RTT) keyed by
+// Packet.FlowKey, so callers can see which flows are lossy during a
+// replay capture. It's safe for concurrent use.
+type FlowTracker struct {
+	shards [flowShardCount]*flowShard
+}
+
+// NewFlowTracker returns an empty FlowTracker.
+func NewFlowTracker() *FlowTracker {
+	ft := &FlowTracker{}
+	for i := range ft.shards {
+		ft.shards[i] = &flowShard{flows: make(map[uint64]*flowState)}
+	}
+	return ft
+}
+
+func (ft *FlowTracker) shardFor(key uint64) *flowShard {
+	return ft.shards[key%flowShardCount]
+}
+
+// Observe updates the counters for pckt's flow and returns the flow's
+// key, so callers (e.g. an emitter making per-flow routing decisions)
+// don't need to recompute it.
+func (ft *FlowTracker) Observe(pckt *Packet) uint64 {
+	key := pckt.FlowKey()
+	shard := ft.shardFor(key)
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	fs := shard.flows[key]
+	if fs == nil {
+		fs = &flowState{}
+		shard.flows[key] = fs
+	}
+
+	fs.Segments++
+	fs.Bytes += uint64(len(pckt.Payload))
+	if pckt.RST {
+		fs.RSTs++
+	}
+
+	srcID := flowKeyBytes(pckt.NetworkFlow().Src(), pckt.TransportFlow().Src())
+	if !fs.haveRef {
+		fs.haveRef = true
+		fs.refSrc = srcID
+	}
+	dir := &fs.fwd
+	if !bytes.Equal(srcID, fs.refSrc) {
+		dir = &fs.rev
+	}
+
+	segEnd := pckt.Seq + uint32(len(pckt.Payload))
+	// SYN and FIN each occupy one sequence number.
+	if pckt.SYN {
+		segEnd++
+	}
+	if pckt.FIN {
+		segEnd++
+	}
+	switch {
+	case !dir.haveSeq:
+		dir.haveSeq = true
+		dir.nextSeq = segEnd
+	case pckt.Seq == dir.nextSeq:
+		dir.nextSeq = segEnd
+	case seqBefore(pckt.Seq, dir.nextSeq):
+		fs.Retransmits++
+	default:
+		fs.OutOfOrder++
+		dir.nextSeq = segEnd
+	}
+
+	if pckt.SYN && !pckt.ACK && fs.synAt.IsZero() {
+		fs.synAt = pckt.Timestamp
+	}
+	if pckt.SYN && pckt.ACK && fs.synAckAt.IsZero() {
+		fs.synAckAt = pckt.Timestamp
+	}
+	if fs.RTT == 0 && !fs.synAt.IsZero() && !fs.synAckAt.IsZero() {
+		fs.RTT = fs.synAckAt.Sub(fs.synAt)
+	}
+
+	return key
+}
+
+// Snapshot returns a point-in-time copy of every tracked flow's
+// counters, suitable for a Prometheus-style scrape.
+func (ft *FlowTracker) Snapshot() []FlowSnapshot {
+	var out []FlowSnapshot
+	for _, shard := range ft.shards {
+		shard.mu.Lock()
+		for key, fs := range shard.flows {
+			out = append(out, FlowSnapshot{Key: key, Counters: fs.FlowCounters})
+		}
+		shard.mu.Unlock()
+	}
+	return out
+}
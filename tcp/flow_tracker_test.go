@@ -0,0 +1,188 @@
+package tcp
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// segPacket builds a fully serialized, then re-decoded, TCP segment so
+// that derived fields like TransportFlow (which reads the raw header
+// bytes, not the parsed struct fields) behave exactly as they would for
+// a captured packet.
+func segPacket(src, dst net.IP, srcPort, dstPort layers.TCPPort, seq uint32, payload []byte, ts time.Time, flags ...string) *Packet {
+	eth := &layers.Ethernet{
+		SrcMAC:       net.HardwareAddr{0, 0, 0, 0, 0, 1},
+		DstMAC:       net.HardwareAddr{0, 0, 0, 0, 0, 2},
+		EthernetType: layers.EthernetTypeIPv4,
+	}
+	ip := &layers.IPv4{
+		Version:  4,
+		IHL:      5,
+		TTL:      64,
+		Protocol: layers.IPProtocolTCP,
+		SrcIP:    src,
+		DstIP:    dst,
+	}
+	tcpHdr := &layers.TCP{SrcPort: srcPort, DstPort: dstPort, Seq: seq, Window: 1024}
+	for _, f := range flags {
+		switch f {
+		case "SYN":
+			tcpHdr.SYN = true
+		case "ACK":
+			tcpHdr.ACK = true
+		case "RST":
+			tcpHdr.RST = true
+		}
+	}
+	if err := tcpHdr.SetNetworkLayerForChecksum(ip); err != nil {
+		panic(err)
+	}
+
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if err := gopacket.SerializeLayers(buf, opts, eth, ip, tcpHdr, gopacket.Payload(payload)); err != nil {
+		panic(err)
+	}
+
+	raw := gopacket.NewPacket(buf.Bytes(), layers.LayerTypeEthernet, gopacket.Default)
+	raw.Metadata().Timestamp = ts
+
+	pckt, err := ParsePacket(raw)
+	if err != nil {
+		panic(err)
+	}
+	return pckt
+}
+
+func TestPacket_FlowKeyIsDirectionIndependent(t *testing.T) {
+	a := net.IPv4(10, 0, 0, 1)
+	b := net.IPv4(10, 0, 0, 2)
+	now := time.Unix(1000, 0)
+
+	client := segPacket(a, b, 40000, 80, 1, []byte("req"), now)
+	server := segPacket(b, a, 80, 40000, 500, []byte("resp"), now)
+
+	if client.FlowKey() != server.FlowKey() {
+		t.Fatalf("expected both directions of a connection to share a FlowKey")
+	}
+
+	other := segPacket(a, b, 40000, 443, 1, []byte("req"), now)
+	if client.FlowKey() == other.FlowKey() {
+		t.Fatalf("expected a different destination port to produce a different FlowKey")
+	}
+}
+
+func TestFlowTracker_CountsBytesAndSegments(t *testing.T) {
+	ft := NewFlowTracker()
+	a := net.IPv4(10, 0, 0, 1)
+	b := net.IPv4(10, 0, 0, 2)
+	now := time.Unix(1000, 0)
+
+	p1 := segPacket(a, b, 40000, 80, 100, []byte("hello"), now, "ACK")
+	p2 := segPacket(a, b, 40000, 80, 105, []byte("world!"), now.Add(time.Millisecond), "ACK")
+	ft.Observe(p1)
+	key := ft.Observe(p2)
+
+	snap := ft.Snapshot()
+	var found *FlowCounters
+	for i := range snap {
+		if snap[i].Key == key {
+			found = &snap[i].Counters
+		}
+	}
+	if found == nil {
+		t.Fatalf("flow not found in snapshot")
+	}
+	if found.Segments != 2 {
+		t.Fatalf("Segments = %d, want 2", found.Segments)
+	}
+	if found.Bytes != uint64(len("hello")+len("world!")) {
+		t.Fatalf("Bytes = %d, want %d", found.Bytes, len("hello")+len("world!"))
+	}
+	if found.Retransmits != 0 || found.OutOfOrder != 0 {
+		t.Fatalf("expected in-order segments to not be flagged")
+	}
+}
+
+func TestFlowTracker_DetectsRetransmit(t *testing.T) {
+	ft := NewFlowTracker()
+	a := net.IPv4(10, 0, 0, 1)
+	b := net.IPv4(10, 0, 0, 2)
+	now := time.Unix(1000, 0)
+
+	ft.Observe(segPacket(a, b, 40000, 80, 100, []byte("hello"), now, "ACK"))
+	key := ft.Observe(segPacket(a, b, 40000, 80, 100, []byte("hello"), now.Add(time.Millisecond), "ACK"))
+
+	snap := ft.Snapshot()
+	for _, s := range snap {
+		if s.Key == key && s.Counters.Retransmits != 1 {
+			t.Fatalf("Retransmits = %d, want 1", s.Counters.Retransmits)
+		}
+	}
+}
+
+func TestFlowTracker_DetectsOutOfOrder(t *testing.T) {
+	ft := NewFlowTracker()
+	a := net.IPv4(10, 0, 0, 1)
+	b := net.IPv4(10, 0, 0, 2)
+	now := time.Unix(1000, 0)
+
+	ft.Observe(segPacket(a, b, 40000, 80, 100, []byte("hello"), now, "ACK"))
+	key := ft.Observe(segPacket(a, b, 40000, 80, 200, []byte("skip-ahead"), now.Add(time.Millisecond), "ACK"))
+
+	snap := ft.Snapshot()
+	for _, s := range snap {
+		if s.Key == key && s.Counters.OutOfOrder != 1 {
+			t.Fatalf("OutOfOrder = %d, want 1", s.Counters.OutOfOrder)
+		}
+	}
+}
+
+func TestFlowTracker_BidirectionalTrafficNotFlaggedAsLossy(t *testing.T) {
+	ft := NewFlowTracker()
+	client := net.IPv4(10, 0, 0, 1)
+	server := net.IPv4(10, 0, 0, 2)
+	now := time.Unix(1000, 0)
+
+	// Client and server each send two in-order segments on their own,
+	// unrelated sequence spaces.
+	ft.Observe(segPacket(client, server, 40000, 80, 1000, make([]byte, 14), now, "ACK"))
+	ft.Observe(segPacket(server, client, 80, 40000, 500000, make([]byte, 15), now.Add(time.Millisecond), "ACK"))
+	ft.Observe(segPacket(client, server, 40000, 80, 1014, make([]byte, 1), now.Add(2*time.Millisecond), "ACK"))
+	key := ft.Observe(segPacket(server, client, 80, 40000, 500015, make([]byte, 1), now.Add(3*time.Millisecond), "ACK"))
+
+	snap := ft.Snapshot()
+	var found *FlowCounters
+	for i := range snap {
+		if snap[i].Key == key {
+			found = &snap[i].Counters
+		}
+	}
+	if found == nil {
+		t.Fatalf("flow not found in snapshot")
+	}
+	if found.Retransmits != 0 || found.OutOfOrder != 0 {
+		t.Fatalf("in-order traffic on independent sequence spaces flagged as lossy: %+v", found)
+	}
+}
+
+func TestFlowTracker_ComputesHandshakeRTT(t *testing.T) {
+	ft := NewFlowTracker()
+	a := net.IPv4(10, 0, 0, 1)
+	b := net.IPv4(10, 0, 0, 2)
+	now := time.Unix(1000, 0)
+
+	ft.Observe(segPacket(a, b, 40000, 80, 1, nil, now, "SYN"))
+	key := ft.Observe(segPacket(b, a, 80, 40000, 1, nil, now.Add(15*time.Millisecond), "SYN", "ACK"))
+
+	snap := ft.Snapshot()
+	for _, s := range snap {
+		if s.Key == key && s.Counters.RTT != 15*time.Millisecond {
+			t.Fatalf("RTT = %s, want 15ms", s.Counters.RTT)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package tcp
+
+// fragment is one arrived piece of a datagram awaiting reassembly.
+// Shared between IPDefragmenter (IPv4) and IPv6Defragmenter.
+type fragment struct {
+	offset int // byte offset of this fragment's data within the datagram
+	data   []byte
+	last   bool // this fragment was the final one (no more-fragments flag)
+}
+
+// fragmentsOverlap reports whether [offset, offset+n) overlaps any
+// fragment already buffered. Exact duplicates are also reported as
+// overlaps so they're dropped rather than double-counted.
+func fragmentsOverlap(frags []fragment, offset, n int) bool {
+	end := offset + n
+	for _, f := range frags {
+		fEnd := f.offset + len(f.data)
+		if offset < fEnd && f.offset < end {
+			return true
+		}
+	}
+	return false
+}
+
+// fragmentsComplete reports whether every byte from 0 to final is
+// covered by a buffered fragment, with no gaps.
+func fragmentsComplete(frags []fragment, final int) bool {
+	type interval struct{ start, end int }
+	ivs := make([]interval, 0, len(frags))
+	for _, f := range frags {
+		ivs = append(ivs, interval{f.offset, f.offset + len(f.data)})
+	}
+	for i := 1; i < len(ivs); i++ {
+		v := ivs[i]
+		j := i - 1
+		for j >= 0 && ivs[j].start > v.start {
+			ivs[j+1] = ivs[j]
+			j--
+		}
+		ivs[j+1] = v
+	}
+
+	next := 0
+	for _, iv := range ivs {
+		if iv.start > next {
+			return false
+		}
+		if iv.end > next {
+			next = iv.end
+		}
+	}
+	return next >= final
+}
@@ -0,0 +1,212 @@
+package tcp
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// defaultFragmentTTL is how long a partially reassembled datagram may go
+// without a new fragment before it's considered abandoned and evicted.
+const defaultFragmentTTL = 30 * time.Second
+
+// defaultFragmentByteCap bounds how many payload bytes the buffered
+// fragments of a single partial datagram may occupy; the fragment that
+// pushes it over the cap causes that whole datagram to be dropped, so a
+// peer that never completes a datagram can't grow memory forever.
+const defaultFragmentByteCap = 1 << 20 // 1MiB
+
+// fragKey identifies an IPv4 datagram being reassembled.
+type fragKey struct {
+	src, dst string
+	protocol layers.IPProtocol
+	id       uint16
+}
+
+// partialDatagram tracks the fragments seen so far for one fragKey.
+type partialDatagram struct {
+	base     layers.IPv4 // header of the first (offset 0) fragment seen
+	haveBase bool
+	frags    []fragment
+	size     int // total buffered payload bytes across frags
+	final    int // total datagram length, known once the last fragment arrives; -1 until then
+	created  time.Time
+	touched  time.Time
+}
+
+// DefragStats holds running counters for an IPDefragmenter.
+type DefragStats struct {
+	Reassembled uint64
+	Dropped     uint64 // overlapping/duplicate fragments dropped, plus partial datagrams dropped for exceeding the byte cap
+	TimedOut    uint64 // partial datagrams evicted for exceeding the TTL
+}
+
+// IPDefragmenter reassembles fragmented IPv4 datagrams so ParsePacket
+// sees one whole TCP segment instead of a run of unusable fragments.
+// It's safe for concurrent use.
+type IPDefragmenter struct {
+	mu    sync.Mutex
+	flows map[fragKey]*partialDatagram
+
+	ttl     time.Duration
+	byteCap int
+
+	stats DefragStats
+}
+
+// NewIPDefragmenter returns an IPDefragmenter with the default TTL and
+// per-flow byte cap.
+func NewIPDefragmenter() *IPDefragmenter {
+	return &IPDefragmenter{
+		flows:   make(map[fragKey]*partialDatagram),
+		ttl:     defaultFragmentTTL,
+		byteCap: defaultFragmentByteCap,
+	}
+}
+
+// Process feeds one IPv4 layer through the defragmenter. If ip isn't
+// fragmented (no MF flag and zero FragOffset), it's returned unchanged
+// along with payload, ok=true. If it's a fragment, Process buffers it
+// and returns ok=false until the datagram is complete, at which point
+// it returns the reassembled IPv4 header and payload.
+func (d *IPDefragmenter) Process(ip *layers.IPv4, payload []byte) (out *layers.IPv4, outPayload []byte, ok bool) {
+	if ip.Flags&layers.IPv4MoreFragments == 0 && ip.FragOffset == 0 {
+		return ip, payload, true
+	}
+
+	key := fragKey{src: string(ip.SrcIP), dst: string(ip.DstIP), protocol: ip.Protocol, id: ip.Id}
+	offset := int(ip.FragOffset) * 8
+	last := ip.Flags&layers.IPv4MoreFragments == 0
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.evictExpiredLocked()
+
+	pd := d.flows[key]
+	if pd == nil {
+		pd = &partialDatagram{final: -1, created: time.Now()}
+		d.flows[key] = pd
+	}
+	pd.touched = time.Now()
+
+	if fragmentsOverlap(pd.frags, offset, len(payload)) {
+		d.stats.Dropped++
+		return nil, nil, false
+	}
+
+	// Only commit the header/final-length state once the fragment has
+	// passed the overlap check, so a rejected fragment can't poison the
+	// datagram that's still legitimately being reassembled.
+	if offset == 0 {
+		pd.base = *ip
+		pd.haveBase = true
+	}
+	if last {
+		pd.final = offset + len(payload)
+	}
+
+	buf := make([]byte, len(payload))
+	copy(buf, payload)
+	pd.frags = append(pd.frags, fragment{offset: offset, data: buf, last: last})
+	pd.size += len(buf)
+
+	if pd.size > d.byteCap {
+		delete(d.flows, key)
+		d.stats.Dropped++
+		return nil, nil, false
+	}
+
+	if !pd.haveBase || pd.final < 0 || !fragmentsComplete(pd.frags, pd.final) {
+		return nil, nil, false
+	}
+
+	delete(d.flows, key)
+	d.stats.Reassembled++
+
+	reassembled := make([]byte, pd.final)
+	for _, f := range pd.frags {
+		copy(reassembled[f.offset:], f.data)
+	}
+
+	hdr := pd.base
+	hdr.Flags &^= layers.IPv4MoreFragments
+	hdr.FragOffset = 0
+	hdr.Length = uint16(int(hdr.IHL)*4 + pd.final)
+
+	return &hdr, reassembled, true
+}
+
+// evictExpiredLocked drops partial datagrams that haven't seen a
+// fragment within the configured TTL. Callers must hold d.mu.
+func (d *IPDefragmenter) evictExpiredLocked() {
+	if len(d.flows) == 0 {
+		return
+	}
+	cutoff := time.Now().Add(-d.ttl)
+	for k, pd := range d.flows {
+		if pd.touched.Before(cutoff) {
+			delete(d.flows, k)
+			d.stats.TimedOut++
+		}
+	}
+}
+
+// Stats returns a snapshot of the defragmenter's counters.
+func (d *IPDefragmenter) Stats() DefragStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.stats
+}
+
+// reassemblePacket rebuilds a decodable gopacket.Packet from a
+// reassembled IPv4 header/payload pair, reusing the original packet's
+// link layer bytes so ParsePacket can decode it exactly like any other
+// captured frame.
+func reassemblePacket(orig gopacket.Packet, ip4 *layers.IPv4, payload []byte) (gopacket.Packet, error) {
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{}
+	if err := gopacket.SerializeLayers(buf, opts, ip4, gopacket.Payload(payload)); err != nil {
+		return nil, err
+	}
+
+	raw := buf.Bytes()
+	linkType := layers.LayerTypeIPv4
+	if link := orig.LinkLayer(); link != nil {
+		linkType = link.LayerType()
+		raw = append(append([]byte{}, link.LayerContents()...), raw...)
+	}
+
+	pkt := gopacket.NewPacket(raw, linkType, gopacket.Lazy)
+	pkt.Metadata().Timestamp = orig.Metadata().Timestamp
+	return pkt, nil
+}
+
+// ParsePacketReassembled behaves like ParsePacket, but first passes the
+// packet's IPv4 layer (if any) through defrag. While a datagram is
+// still incomplete, or an overlapping/duplicate fragment was dropped,
+// it returns pckt == nil, err == nil and the caller should move on to
+// the next raw packet; once the last fragment arrives it returns the
+// fully reassembled *Packet.
+func ParsePacketReassembled(packet gopacket.Packet, defrag *IPDefragmenter) (pckt *Packet, err error) {
+	ip4, ok := packet.NetworkLayer().(*layers.IPv4)
+	if !ok || defrag == nil {
+		return ParsePacket(packet)
+	}
+
+	hdr, payload, ok := defrag.Process(ip4, ip4.Payload)
+	if !ok {
+		return nil, nil
+	}
+	if hdr == ip4 {
+		return ParsePacket(packet)
+	}
+
+	reassembled, err := reassemblePacket(packet, hdr, payload)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePacket(reassembled)
+}
@@ -0,0 +1,142 @@
+package tcp
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/gopacket/layers"
+)
+
+func fragIP(id uint16, fragOffset uint16, more bool) *layers.IPv4 {
+	var flags layers.IPv4Flag
+	if more {
+		flags = layers.IPv4MoreFragments
+	}
+	return &layers.IPv4{
+		SrcIP:      net.IPv4(10, 0, 0, 1),
+		DstIP:      net.IPv4(10, 0, 0, 2),
+		Protocol:   layers.IPProtocolTCP,
+		Id:         id,
+		IHL:        5,
+		Flags:      flags,
+		FragOffset: fragOffset,
+	}
+}
+
+func TestIPDefragmenter_InOrder(t *testing.T) {
+	d := NewIPDefragmenter()
+
+	first := []byte("HELLO!!!") // 8 bytes: non-final fragments must be a multiple of 8
+	second := []byte("WORLD!!!")
+
+	if _, _, ok := d.Process(fragIP(1, 0, true), first); ok {
+		t.Fatalf("expected first fragment to be incomplete")
+	}
+
+	hdr, payload, ok := d.Process(fragIP(1, uint16(len(first))/8, false), second)
+	if !ok {
+		t.Fatalf("expected reassembly to complete")
+	}
+	if string(payload) != string(first)+string(second) {
+		t.Fatalf("unexpected payload: %q", payload)
+	}
+	if hdr.Flags&layers.IPv4MoreFragments != 0 {
+		t.Fatalf("reassembled header should not have MF set")
+	}
+
+	if got := d.Stats().Reassembled; got != 1 {
+		t.Fatalf("Reassembled = %d, want 1", got)
+	}
+}
+
+func TestIPDefragmenter_OutOfOrder(t *testing.T) {
+	d := NewIPDefragmenter()
+
+	first := []byte("AAAAAAAA") // 8 bytes, offset unit
+	second := []byte("BBBBBBBB")
+	third := []byte("CCCC")
+
+	if _, _, ok := d.Process(fragIP(2, 2, false), third); ok {
+		t.Fatalf("expected 3rd fragment (arrived first) to be incomplete")
+	}
+	if _, _, ok := d.Process(fragIP(2, 1, true), second); ok {
+		t.Fatalf("expected 2nd fragment to be incomplete")
+	}
+	_, payload, ok := d.Process(fragIP(2, 0, true), first)
+	if !ok {
+		t.Fatalf("expected reassembly to complete once the base fragment arrives")
+	}
+	if string(payload) != "AAAAAAAABBBBBBBBCCCC" {
+		t.Fatalf("unexpected reassembled payload: %q", payload)
+	}
+}
+
+func TestIPDefragmenter_DuplicateDropped(t *testing.T) {
+	d := NewIPDefragmenter()
+
+	data := []byte("DUPDATA!")
+	if _, _, ok := d.Process(fragIP(3, 0, true), data); ok {
+		t.Fatalf("expected incomplete after first fragment")
+	}
+	if _, _, ok := d.Process(fragIP(3, 0, true), data); ok {
+		t.Fatalf("duplicate fragment must not complete reassembly")
+	}
+	if got := d.Stats().Dropped; got != 1 {
+		t.Fatalf("Dropped = %d, want 1", got)
+	}
+}
+
+func TestIPDefragmenter_OverlapDropped(t *testing.T) {
+	d := NewIPDefragmenter()
+
+	if _, _, ok := d.Process(fragIP(4, 0, true), []byte("0123456789ABCDEF")); ok {
+		t.Fatalf("expected incomplete after first fragment")
+	}
+	// Overlaps bytes [8,16) of the first fragment.
+	if _, _, ok := d.Process(fragIP(4, 1, false), []byte("XXXXXXXX")); ok {
+		t.Fatalf("overlapping fragment must not complete reassembly")
+	}
+	if got := d.Stats().Dropped; got != 1 {
+		t.Fatalf("Dropped = %d, want 1", got)
+	}
+}
+
+func TestIPDefragmenter_RejectedOverlapDoesNotCorruptState(t *testing.T) {
+	d := NewIPDefragmenter()
+
+	// A real 16-byte fragment at offset 0.
+	if _, _, ok := d.Process(fragIP(6, 0, true), []byte("0123456789ABCDEF")); ok {
+		t.Fatalf("expected incomplete after first fragment")
+	}
+
+	// A spoofed "final" fragment overlapping bytes [8,12) that must be
+	// rejected without corrupting pd.final for the legitimate datagram.
+	if _, _, ok := d.Process(fragIP(6, 1, false), []byte("XXXX")); ok {
+		t.Fatalf("overlapping fragment must not complete reassembly")
+	}
+
+	// A genuinely new, non-overlapping fragment must not panic and must
+	// still complete the original 16-byte datagram.
+	hdr, payload, ok := d.Process(fragIP(6, 2, false), []byte("GHIJKLMN"))
+	if !ok {
+		t.Fatalf("expected reassembly to complete")
+	}
+	if string(payload) != "0123456789ABCDEFGHIJKLMN" {
+		t.Fatalf("unexpected reassembled payload: %q", payload)
+	}
+	if hdr.Flags&layers.IPv4MoreFragments != 0 {
+		t.Fatalf("reassembled header should not have MF set")
+	}
+}
+
+func TestIPDefragmenter_ByteCapEvictsFlow(t *testing.T) {
+	d := NewIPDefragmenter()
+	d.byteCap = 10
+
+	if _, _, ok := d.Process(fragIP(5, 0, true), []byte("0123456789ABCDEF")); ok {
+		t.Fatalf("expected incomplete fragment to be buffered then evicted")
+	}
+	if len(d.flows) != 0 {
+		t.Fatalf("expected flow to be evicted once over the byte cap")
+	}
+}
@@ -0,0 +1,191 @@
+package tcp
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// frag6Key identifies an IPv6 datagram being reassembled, keyed on the
+// Fragment extension header's Identification field per RFC 8200.
+type frag6Key struct {
+	src, dst string
+	id       uint32
+}
+
+// partialDatagram6 tracks the fragments seen so far for one frag6Key.
+type partialDatagram6 struct {
+	base     layers.IPv6
+	haveBase bool
+	frags    []fragment
+	size     int
+	final    int // -1 until the last fragment (MoreFragments == false) arrives
+	touched  time.Time
+}
+
+// IPv6Defragmenter reassembles IPv6 datagrams fragmented via the
+// Fragment extension header, analogous to IPDefragmenter for IPv4.
+// It's safe for concurrent use.
+type IPv6Defragmenter struct {
+	mu    sync.Mutex
+	flows map[frag6Key]*partialDatagram6
+
+	ttl     time.Duration
+	byteCap int
+
+	stats DefragStats
+}
+
+// NewIPv6Defragmenter returns an IPv6Defragmenter with the default TTL
+// and per-flow byte cap.
+func NewIPv6Defragmenter() *IPv6Defragmenter {
+	return &IPv6Defragmenter{
+		flows:   make(map[frag6Key]*partialDatagram6),
+		ttl:     defaultFragmentTTL,
+		byteCap: defaultFragmentByteCap,
+	}
+}
+
+// Process feeds one IPv6 layer and its Fragment extension header
+// through the defragmenter. frag is nil when ip6 carries no Fragment
+// header, in which case Process returns it unchanged. Otherwise it
+// buffers the fragment and returns ok=false until the datagram is
+// complete, at which point it returns the reassembled IPv6 header
+// (with the Fragment header removed and Length fixed up) and payload.
+func (d *IPv6Defragmenter) Process(ip6 *layers.IPv6, frag *layers.IPv6Fragment, payload []byte) (out *layers.IPv6, outPayload []byte, ok bool) {
+	if frag == nil {
+		return ip6, payload, true
+	}
+
+	key := frag6Key{src: string(ip6.SrcIP), dst: string(ip6.DstIP), id: frag.Identification}
+	offset := int(frag.FragmentOffset) * 8
+	last := !frag.MoreFragments
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.evictExpiredLocked()
+
+	pd := d.flows[key]
+	if pd == nil {
+		pd = &partialDatagram6{final: -1}
+		d.flows[key] = pd
+	}
+	pd.touched = time.Now()
+
+	if fragmentsOverlap(pd.frags, offset, len(payload)) {
+		d.stats.Dropped++
+		return nil, nil, false
+	}
+
+	// Only commit the header/final-length state once the fragment has
+	// passed the overlap check, so a rejected fragment can't poison the
+	// datagram that's still legitimately being reassembled.
+	if offset == 0 {
+		pd.base = *ip6
+		pd.haveBase = true
+	}
+	if last {
+		pd.final = offset + len(payload)
+	}
+
+	buf := make([]byte, len(payload))
+	copy(buf, payload)
+	pd.frags = append(pd.frags, fragment{offset: offset, data: buf, last: last})
+	pd.size += len(buf)
+
+	if pd.size > d.byteCap {
+		delete(d.flows, key)
+		d.stats.Dropped++
+		return nil, nil, false
+	}
+
+	if !pd.haveBase || pd.final < 0 || !fragmentsComplete(pd.frags, pd.final) {
+		return nil, nil, false
+	}
+
+	delete(d.flows, key)
+	d.stats.Reassembled++
+
+	reassembled := make([]byte, pd.final)
+	for _, f := range pd.frags {
+		copy(reassembled[f.offset:], f.data)
+	}
+
+	hdr := pd.base
+	// hdr.HopByHop, if set, still points at the original datagram's
+	// Hop-by-Hop header (whose own NextHeader says Fragment). Clear it so
+	// layers.IPv6.SerializeTo doesn't re-emit that stale header ahead of
+	// what's now raw upper-layer bytes.
+	hdr.HopByHop = nil
+	hdr.NextHeader = frag.NextHeader
+	hdr.Length = uint16(pd.final)
+
+	return &hdr, reassembled, true
+}
+
+// evictExpiredLocked drops partial datagrams that haven't seen a
+// fragment within the configured TTL. Callers must hold d.mu.
+func (d *IPv6Defragmenter) evictExpiredLocked() {
+	if len(d.flows) == 0 {
+		return
+	}
+	cutoff := time.Now().Add(-d.ttl)
+	for k, pd := range d.flows {
+		if pd.touched.Before(cutoff) {
+			delete(d.flows, k)
+			d.stats.TimedOut++
+		}
+	}
+}
+
+// Stats returns a snapshot of the defragmenter's counters.
+func (d *IPv6Defragmenter) Stats() DefragStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.stats
+}
+
+// ParsePacketReassembled6 behaves like ParsePacketReassembled but for
+// IPv6's Fragment extension header: it returns pckt == nil, err == nil
+// while a datagram is still incomplete, and the fully reassembled
+// *Packet once the last fragment arrives.
+func ParsePacketReassembled6(packet gopacket.Packet, defrag *IPv6Defragmenter) (pckt *Packet, err error) {
+	ip6, ok := packet.NetworkLayer().(*layers.IPv6)
+	if !ok || defrag == nil {
+		return ParsePacket(packet)
+	}
+
+	var frag *layers.IPv6Fragment
+	fragPayload := ip6.LayerPayload()
+	if f, ok := packet.Layer(layers.LayerTypeIPv6Fragment).(*layers.IPv6Fragment); ok {
+		frag = f
+		fragPayload = f.LayerPayload()
+	}
+
+	hdr, payload, ok := defrag.Process(ip6, frag, fragPayload)
+	if !ok {
+		return nil, nil
+	}
+	if hdr == ip6 {
+		return ParsePacket(packet)
+	}
+
+	buf := gopacket.NewSerializeBuffer()
+	if err = gopacket.SerializeLayers(buf, gopacket.SerializeOptions{}, hdr, gopacket.Payload(payload)); err != nil {
+		return nil, err
+	}
+
+	raw := buf.Bytes()
+	linkType := layers.LayerTypeIPv6
+	if link := packet.LinkLayer(); link != nil {
+		linkType = link.LayerType()
+		raw = append(append([]byte{}, link.LayerContents()...), raw...)
+	}
+
+	reassembled := gopacket.NewPacket(raw, linkType, gopacket.Lazy)
+	reassembled.Metadata().Timestamp = packet.Metadata().Timestamp
+	return ParsePacket(reassembled)
+}
@@ -0,0 +1,70 @@
+package tcp
+
+import (
+	"testing"
+
+	"github.com/google/gopacket/layers"
+)
+
+func TestIPv6Defragmenter_OverlapDropped(t *testing.T) {
+	d := NewIPv6Defragmenter()
+	base := &layers.IPv6{}
+
+	if _, _, ok := d.Process(base, fragIP6(2, 0, true), []byte("0123456789ABCDEF")); ok {
+		t.Fatalf("expected incomplete after first fragment")
+	}
+	// Overlaps bytes [8,16) of the first fragment.
+	if _, _, ok := d.Process(base, fragIP6(2, 1, false), []byte("XXXXXXXX")); ok {
+		t.Fatalf("overlapping fragment must not complete reassembly")
+	}
+	if got := d.Stats().Dropped; got != 1 {
+		t.Fatalf("Dropped = %d, want 1", got)
+	}
+}
+
+func TestIPv6Defragmenter_RejectedOverlapDoesNotCorruptState(t *testing.T) {
+	d := NewIPv6Defragmenter()
+	base := &layers.IPv6{}
+
+	// A real 16-byte fragment at offset 0.
+	if _, _, ok := d.Process(base, fragIP6(3, 0, true), []byte("0123456789ABCDEF")); ok {
+		t.Fatalf("expected incomplete after first fragment")
+	}
+
+	// A spoofed "final" fragment overlapping bytes [8,12) that must be
+	// rejected without corrupting pd.final for the legitimate datagram.
+	if _, _, ok := d.Process(base, fragIP6(3, 1, false), []byte("XXXX")); ok {
+		t.Fatalf("overlapping fragment must not complete reassembly")
+	}
+
+	// A genuinely new, non-overlapping fragment must not panic and must
+	// still complete the original 16-byte datagram.
+	hdr, payload, ok := d.Process(base, fragIP6(3, 2, false), []byte("GHIJKLMN"))
+	if !ok {
+		t.Fatalf("expected reassembly to complete")
+	}
+	if string(payload) != "0123456789ABCDEFGHIJKLMN" {
+		t.Fatalf("unexpected reassembled payload: %q", payload)
+	}
+	if hdr.NextHeader != layers.IPProtocolTCP {
+		t.Fatalf("reassembled header should carry the upper-layer NextHeader")
+	}
+}
+
+func TestIPv6Defragmenter_ClearsHopByHopFromReassembledHeader(t *testing.T) {
+	d := NewIPv6Defragmenter()
+	hbh := &layers.IPv6HopByHop{}
+	hbh.NextHeader = layers.IPProtocolIPv6Fragment
+	base := &layers.IPv6{HopByHop: hbh}
+
+	if _, _, ok := d.Process(base, fragIP6(4, 0, true), []byte("PART-ONE")); ok {
+		t.Fatalf("expected first fragment to be incomplete")
+	}
+	hdr, _, ok := d.Process(base, fragIP6(4, 1, false), []byte("PART-TWO"))
+	if !ok {
+		t.Fatalf("expected reassembly to complete")
+	}
+	if hdr.HopByHop != nil {
+		t.Fatalf("reassembled header must not carry the original Hop-by-Hop header, else SerializeTo re-emits it ahead of the TCP layer")
+	}
+}
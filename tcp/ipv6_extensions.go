@@ -0,0 +1,61 @@
+package tcp
+
+import (
+	"encoding/binary"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// ipv6HopByHopJumbogram is the IPv6 Hop-by-Hop option type carrying the
+// Jumbo Payload length (RFC 2675), used when the fixed 16-bit IPv6
+// header Length field is zero.
+const ipv6HopByHopJumbogram = 0xC2
+
+// ipv6ExtensionHeaderTypes are the extension header layers that can sit
+// between the fixed IPv6 header and the upper-layer (TCP) header.
+var ipv6ExtensionHeaderTypes = map[gopacket.LayerType]bool{
+	layers.LayerTypeIPv6HopByHop:    true,
+	layers.LayerTypeIPv6Routing:     true,
+	layers.LayerTypeIPv6Fragment:    true,
+	layers.LayerTypeIPv6Destination: true,
+}
+
+// jumbogramLength returns the Jumbo Payload length carried in ip6's
+// Hop-by-Hop options, and whether one was found.
+func jumbogramLength(ip6 *layers.IPv6) (uint32, bool) {
+	if ip6.HopByHop == nil {
+		return 0, false
+	}
+	for _, opt := range ip6.HopByHop.Options {
+		if opt.OptionType == ipv6HopByHopJumbogram && len(opt.OptionData) == 4 {
+			return binary.BigEndian.Uint32(opt.OptionData), true
+		}
+	}
+	return 0, false
+}
+
+// ExtensionHeadersLen returns the combined length, in bytes, of every
+// IPv6 extension header (Hop-by-Hop, Routing, Fragment, Destination)
+// present between the fixed IPv6 header and the TCP header. It's zero
+// for IPv4 packets or IPv6 packets with no extension headers.
+func (pckt *Packet) ExtensionHeadersLen() uint16 {
+	return pckt.ipv6ExtensionsLen
+}
+
+// ipv6ExtSkipper wraps layers.IPv6ExtensionSkipper to accumulate the
+// byte length of every extension header it decodes into *total, since
+// DecodingLayerParser reuses one decoder instance across a chain of
+// headers and only the last one survives once decoding finishes.
+type ipv6ExtSkipper struct {
+	layers.IPv6ExtensionSkipper
+	total *uint16
+}
+
+func (s *ipv6ExtSkipper) DecodeFromBytes(data []byte, df gopacket.DecodeFeedback) error {
+	if err := s.IPv6ExtensionSkipper.DecodeFromBytes(data, df); err != nil {
+		return err
+	}
+	*s.total += uint16(len(s.LayerContents()))
+	return nil
+}
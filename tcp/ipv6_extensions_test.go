@@ -0,0 +1,79 @@
+package tcp
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/google/gopacket/layers"
+)
+
+func TestJumbogramLength(t *testing.T) {
+	data := make([]byte, 4)
+	binary.BigEndian.PutUint32(data, 70000)
+
+	ip6 := &layers.IPv6{
+		HopByHop: &layers.IPv6HopByHop{
+			Options: []*layers.IPv6HopByHopOption{
+				{OptionType: ipv6HopByHopJumbogram, OptionData: data},
+			},
+		},
+	}
+
+	got, ok := jumbogramLength(ip6)
+	if !ok {
+		t.Fatalf("expected jumbogram option to be found")
+	}
+	if got != 70000 {
+		t.Fatalf("jumbogramLength = %d, want 70000", got)
+	}
+}
+
+func TestJumbogramLength_NotPresent(t *testing.T) {
+	ip6 := &layers.IPv6{}
+	if _, ok := jumbogramLength(ip6); ok {
+		t.Fatalf("expected no jumbogram option without HopByHop")
+	}
+}
+
+func fragIP6(id uint32, offset uint16, more bool) *layers.IPv6Fragment {
+	return &layers.IPv6Fragment{
+		FragmentOffset: offset,
+		MoreFragments:  more,
+		Identification: id,
+		NextHeader:     layers.IPProtocolTCP,
+	}
+}
+
+func TestIPv6Defragmenter_Reassembles(t *testing.T) {
+	d := NewIPv6Defragmenter()
+	base := &layers.IPv6{}
+
+	first := []byte("PART-ONE")
+	second := []byte("PART-TWO!!!")
+
+	if _, _, ok := d.Process(base, fragIP6(9, 0, true), first); ok {
+		t.Fatalf("expected first fragment to be incomplete")
+	}
+
+	hdr, payload, ok := d.Process(base, fragIP6(9, uint16(len(first))/8, false), second)
+	if !ok {
+		t.Fatalf("expected reassembly to complete")
+	}
+	if string(payload) != string(first)+string(second) {
+		t.Fatalf("unexpected payload: %q", payload)
+	}
+	if hdr.NextHeader != layers.IPProtocolTCP {
+		t.Fatalf("expected reassembled NextHeader to be restored to TCP")
+	}
+}
+
+func TestIPv6Defragmenter_Unfragmented(t *testing.T) {
+	d := NewIPv6Defragmenter()
+	ip6 := &layers.IPv6{}
+	payload := []byte("no fragmentation here")
+
+	hdr, out, ok := d.Process(ip6, nil, payload)
+	if !ok || hdr != ip6 || string(out) != string(payload) {
+		t.Fatalf("expected unfragmented packet to pass through unchanged")
+	}
+}
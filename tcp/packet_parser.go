@@ -0,0 +1,161 @@
+package tcp
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// ErrNoTCPLayer is returned by PacketParser.Parse when the decoded packet
+// doesn't carry an IPv4/IPv6 network layer with a TCP transport layer on
+// top, mirroring the packets ParsePacket silently drops.
+var ErrNoTCPLayer = errors.New("tcp: packet has no IPv4/IPv6+TCP layers")
+
+// PacketParser decodes raw packet bytes into a *Packet using
+// gopacket.DecodingLayerParser, reusing its layer structs and *Packet
+// across calls instead of allocating per packet. The *Packet returned
+// by Parse is only valid until the next call to Parse; use ParsePacket
+// instead if a packet needs to outlive that.
+type PacketParser struct {
+	decoder *gopacket.DecodingLayerParser
+	decoded []gopacket.LayerType
+
+	eth layers.Ethernet
+	ip4 layers.IPv4
+	ip6 layers.IPv6
+
+	// ext skips over Routing/Fragment/Destination extension headers so
+	// DecodingLayerParser can reach TCP. A leading Hop-by-Hop header is
+	// handled separately, since layers.IPv6.DecodeFromBytes decodes it
+	// itself and never hands it to ext.
+	ext    ipv6ExtSkipper
+	extLen uint16 // combined byte length of extension headers seen by ext during the current Parse call
+
+	tcp layers.TCP
+	pld gopacket.Payload
+
+	pckt Packet
+}
+
+// NewPacketParser returns a PacketParser ready to decode Ethernet-framed
+// IPv4/IPv6 TCP packets, including IPv6 extension headers. It doesn't
+// reassemble fragments; use ParsePacketReassembled (IPv4) or
+// ParsePacketReassembled6 (IPv6) for that.
+func NewPacketParser() *PacketParser {
+	p := &PacketParser{
+		decoded: make([]gopacket.LayerType, 0, 4),
+	}
+	p.ext.total = &p.extLen
+	p.decoder = gopacket.NewDecodingLayerParser(
+		layers.LayerTypeEthernet,
+		&p.eth, &p.ip4, &p.ip6, &p.tcp, &p.pld, &p.ext,
+	)
+	p.decoder.IgnoreUnsupported = true
+	return p
+}
+
+// Parse decodes data in place, returning the parser's reusable *Packet.
+// timestamp is copied onto the packet; a zero timestamp is replaced
+// with time.Now().
+func (p *PacketParser) Parse(data []byte, timestamp time.Time) (*Packet, error) {
+	p.extLen = 0
+	if err := p.decoder.DecodeLayers(data, &p.decoded); err != nil {
+		return nil, err
+	}
+
+	pckt := &p.pckt
+	*pckt = Packet{Timestamp: timestamp}
+	if pckt.Timestamp.Equal(time.Time{}) {
+		pckt.Timestamp = time.Now()
+	}
+
+	var sawNetwork, sawTCP bool
+	for _, t := range p.decoded {
+		switch t {
+		case layers.LayerTypeIPv4:
+			pckt.NetworkLayer = &p.ip4
+			sawNetwork = true
+		case layers.LayerTypeIPv6:
+			pckt.NetworkLayer = &p.ip6
+			sawNetwork = true
+		case layers.LayerTypeTCP:
+			pckt.TCP = &p.tcp
+			pckt.DataOffset *= 4
+			sawTCP = true
+		}
+	}
+	if !sawNetwork || !sawTCP {
+		return nil, ErrNoTCPLayer
+	}
+
+	// ext never sees a leading Hop-by-Hop header (see field comment), so
+	// add its length back in from the IPv6 layer directly.
+	pckt.ipv6ExtensionsLen = p.extLen
+	if pckt.NetworkLayer == &p.ip6 && p.ip6.HopByHop != nil {
+		pckt.ipv6ExtensionsLen += uint16(len(p.ip6.HopByHop.LayerContents()))
+	}
+	pckt.DataLayer = &p.pld
+	pckt.Lost = calcLost(pckt)
+
+	return pckt, nil
+}
+
+// ParserMode selects the decoding strategy a Parser uses.
+type ParserMode int
+
+const (
+	// ModeSafe decodes each packet independently with gopacket's
+	// general/lazy decoder (ParsePacket's behavior). Every returned
+	// *Packet owns its own layers and remains valid indefinitely.
+	ModeSafe ParserMode = iota
+
+	// ModeFast decodes with a PacketParser, reusing layer structs and
+	// the *Packet across calls. Callers must fully consume a *Packet
+	// (e.g. hand it off to a Message) before calling Parse again.
+	ModeFast
+)
+
+// ParserOption configures a Parser returned by NewParser.
+type ParserOption func(*Parser)
+
+// WithMode sets the decoding strategy. The default, if WithMode isn't
+// passed, is ModeSafe.
+func WithMode(mode ParserMode) ParserOption {
+	return func(p *Parser) { p.mode = mode }
+}
+
+// Parser decodes raw packets under either ModeSafe or ModeFast,
+// letting callers pick the tradeoff between per-packet allocation and
+// reuse without changing call sites.
+type Parser struct {
+	mode ParserMode
+	fast *PacketParser
+}
+
+// NewParser builds a Parser according to opts. In ModeFast it eagerly
+// allocates the underlying PacketParser.
+func NewParser(opts ...ParserOption) *Parser {
+	p := &Parser{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.mode == ModeFast {
+		p.fast = NewPacketParser()
+	}
+	return p
+}
+
+// Parse decodes an Ethernet-framed packet captured at timestamp. In
+// ModeFast the returned *Packet must be consumed before the next call
+// to Parse; in ModeSafe it's safe to retain.
+func (p *Parser) Parse(data []byte, timestamp time.Time) (*Packet, error) {
+	if p.mode == ModeFast {
+		return p.fast.Parse(data, timestamp)
+	}
+
+	packet := gopacket.NewPacket(data, layers.LayerTypeEthernet, gopacket.Lazy)
+	packet.Metadata().Timestamp = timestamp
+	return ParsePacket(packet)
+}
@@ -0,0 +1,195 @@
+package tcp
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// rawTCPPacket serializes an Ethernet/IPv4/TCP packet with payload.
+func rawTCPPacket(t *testing.T, payload []byte) []byte {
+	t.Helper()
+	eth := &layers.Ethernet{
+		SrcMAC:       net.HardwareAddr{0, 0, 0, 0, 0, 1},
+		DstMAC:       net.HardwareAddr{0, 0, 0, 0, 0, 2},
+		EthernetType: layers.EthernetTypeIPv4,
+	}
+	ip := &layers.IPv4{
+		Version:  4,
+		IHL:      5,
+		TTL:      64,
+		Protocol: layers.IPProtocolTCP,
+		SrcIP:    net.IPv4(10, 0, 0, 1),
+		DstIP:    net.IPv4(10, 0, 0, 2),
+	}
+	tcpHdr := &layers.TCP{SrcPort: 40000, DstPort: 80, Seq: 1, ACK: true, Window: 1024}
+	if err := tcpHdr.SetNetworkLayerForChecksum(ip); err != nil {
+		t.Fatalf("SetNetworkLayerForChecksum: %v", err)
+	}
+
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if err := gopacket.SerializeLayers(buf, opts, eth, ip, tcpHdr, gopacket.Payload(payload)); err != nil {
+		t.Fatalf("SerializeLayers: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// rawTCPOverIPv6HopByHop serializes an Ethernet/IPv6/TCP packet carrying a
+// single 8-byte Hop-by-Hop extension header ahead of the TCP header, the
+// case that stops gopacket.DecodingLayerParser dead in its tracks unless
+// a decoder for it is registered.
+func rawTCPOverIPv6HopByHop(t *testing.T, payload []byte) []byte {
+	t.Helper()
+	eth := &layers.Ethernet{
+		SrcMAC:       net.HardwareAddr{0, 0, 0, 0, 0, 1},
+		DstMAC:       net.HardwareAddr{0, 0, 0, 0, 0, 2},
+		EthernetType: layers.EthernetTypeIPv6,
+	}
+	hbh := &layers.IPv6HopByHop{
+		Options: []*layers.IPv6HopByHopOption{
+			{OptionType: 0x01, OptionData: make([]byte, 4)}, // PadN, brings the header to 8 bytes total
+		},
+	}
+	hbh.NextHeader = layers.IPProtocolTCP
+
+	ip6 := &layers.IPv6{
+		Version:    6,
+		HopLimit:   64,
+		NextHeader: layers.IPProtocolIPv6HopByHop,
+		SrcIP:      net.ParseIP("2001:db8::1"),
+		DstIP:      net.ParseIP("2001:db8::2"),
+		HopByHop:   hbh,
+	}
+	tcpHdr := &layers.TCP{SrcPort: 40000, DstPort: 80, Seq: 1, ACK: true, Window: 1024}
+	if err := tcpHdr.SetNetworkLayerForChecksum(ip6); err != nil {
+		t.Fatalf("SetNetworkLayerForChecksum: %v", err)
+	}
+
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if err := gopacket.SerializeLayers(buf, opts, eth, ip6, tcpHdr, gopacket.Payload(payload)); err != nil {
+		t.Fatalf("SerializeLayers: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestPacketParser_ParsesIPv4TCP(t *testing.T) {
+	raw := rawTCPPacket(t, []byte("hello"))
+
+	p := NewPacketParser()
+	pckt, err := p.Parse(raw, time.Now())
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if pckt.Src() != "10.0.0.1:40000" || pckt.Dst() != "10.0.0.2:80" {
+		t.Fatalf("Src/Dst = %s/%s, want 10.0.0.1:40000/10.0.0.2:80", pckt.Src(), pckt.Dst())
+	}
+	if pckt.Lost != 0 {
+		t.Fatalf("Lost = %d, want 0", pckt.Lost)
+	}
+}
+
+func TestPacketParser_NonTCPReturnsErrNoTCPLayer(t *testing.T) {
+	eth := &layers.Ethernet{
+		SrcMAC:       net.HardwareAddr{0, 0, 0, 0, 0, 1},
+		DstMAC:       net.HardwareAddr{0, 0, 0, 0, 0, 2},
+		EthernetType: layers.EthernetTypeIPv4,
+	}
+	ip := &layers.IPv4{
+		Version: 4, IHL: 5, TTL: 64, Protocol: layers.IPProtocolUDP,
+		SrcIP: net.IPv4(10, 0, 0, 1), DstIP: net.IPv4(10, 0, 0, 2),
+	}
+	udp := &layers.UDP{SrcPort: 1, DstPort: 2}
+	udp.SetNetworkLayerForChecksum(ip)
+
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if err := gopacket.SerializeLayers(buf, opts, eth, ip, udp); err != nil {
+		t.Fatalf("SerializeLayers: %v", err)
+	}
+
+	p := NewPacketParser()
+	if _, err := p.Parse(buf.Bytes(), time.Now()); err != ErrNoTCPLayer {
+		t.Fatalf("err = %v, want ErrNoTCPLayer", err)
+	}
+}
+
+func TestPacketParser_IPv6HopByHopReachesTCPLayer(t *testing.T) {
+	raw := rawTCPOverIPv6HopByHop(t, []byte("hello"))
+
+	p := NewPacketParser()
+	pckt, err := p.Parse(raw, time.Now())
+	if err != nil {
+		t.Fatalf("Parse: %v, want the Hop-by-Hop header to be skipped and TCP reached", err)
+	}
+	if pckt.Src() != "2001:db8::1:40000" || pckt.Dst() != "2001:db8::2:80" {
+		t.Fatalf("Src/Dst = %s/%s, want 2001:db8::1:40000/2001:db8::2:80", pckt.Src(), pckt.Dst())
+	}
+	if got := pckt.ExtensionHeadersLen(); got != 8 {
+		t.Fatalf("ExtensionHeadersLen() = %d, want 8", got)
+	}
+}
+
+func TestPacketParser_ExtensionHeadersLenMatchesSlowPath(t *testing.T) {
+	raw := rawTCPOverIPv6HopByHop(t, []byte("hello"))
+
+	fast, err := NewPacketParser().Parse(raw, time.Now())
+	if err != nil {
+		t.Fatalf("fast Parse: %v", err)
+	}
+
+	packet := gopacket.NewPacket(raw, layers.LayerTypeEthernet, gopacket.Default)
+	safe, err := ParsePacket(packet)
+	if err != nil {
+		t.Fatalf("ParsePacket: %v", err)
+	}
+
+	if fast.ExtensionHeadersLen() != safe.ExtensionHeadersLen() {
+		t.Fatalf("ExtensionHeadersLen mismatch: fast=%d safe=%d", fast.ExtensionHeadersLen(), safe.ExtensionHeadersLen())
+	}
+}
+
+func TestParser_ModeSafeAndModeFastAgree(t *testing.T) {
+	raw := rawTCPPacket(t, []byte("hello"))
+	now := time.Now()
+
+	safe := NewParser()
+	fast := NewParser(WithMode(ModeFast))
+
+	sp, err := safe.Parse(raw, now)
+	if err != nil {
+		t.Fatalf("safe Parse: %v", err)
+	}
+	fp, err := fast.Parse(raw, now)
+	if err != nil {
+		t.Fatalf("fast Parse: %v", err)
+	}
+
+	if sp.Src() != fp.Src() || sp.Dst() != fp.Dst() {
+		t.Fatalf("Src/Dst mismatch: safe=%s/%s fast=%s/%s", sp.Src(), sp.Dst(), fp.Src(), fp.Dst())
+	}
+	if sp.Lost != fp.Lost {
+		t.Fatalf("Lost mismatch: safe=%d fast=%d", sp.Lost, fp.Lost)
+	}
+}
+
+func TestPacketParser_ReusesPacketAcrossCalls(t *testing.T) {
+	p := NewPacketParser()
+
+	first, err := p.Parse(rawTCPPacket(t, []byte("one")), time.Now())
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	second, err := p.Parse(rawTCPPacket(t, []byte("two")), time.Now())
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if first != second {
+		t.Fatalf("expected PacketParser to reuse the same *Packet across calls")
+	}
+}
@@ -29,6 +29,12 @@ type Packet struct {
 	// Application Layer(data layer)
 	DataLayer gopacket.ApplicationLayer
 
+	// Combined length, in bytes, of every IPv6 extension header
+	// (Hop-by-Hop, Routing, Fragment, Destination) between the fixed
+	// IPv6 header and the TCP header. Always zero for IPv4 packets. See
+	// ExtensionHeadersLen.
+	ipv6ExtensionsLen uint16
+
 	// Data info
 	Lost      uint16
 	Timestamp time.Time
@@ -66,6 +72,16 @@ func ParsePacket(packet gopacket.Packet) (pckt *Packet, err error) {
 		return
 	}
 
+	// parsing IPv6 extension headers, if any, so Length/Lost can account
+	// for the bytes they add ahead of the TCP header
+	if pckt.Version() == 6 {
+		for _, l := range packet.Layers() {
+			if ipv6ExtensionHeaderTypes[l.LayerType()] {
+				pckt.ipv6ExtensionsLen += uint16(len(l.LayerContents()))
+			}
+		}
+	}
+
 	// parsing tcp header(transportation layer)
 	if tcp, ok := packet.TransportLayer().(*layers.TCP); ok {
 		pckt.TCP = tcp
@@ -77,14 +93,22 @@ func ParsePacket(packet gopacket.Packet) (pckt *Packet, err error) {
 	// parsing application later(actual data)
 	pckt.DataLayer = packet.ApplicationLayer()
 
-	// calculating lost data
+	pckt.Lost = calcLost(pckt)
+
+	return
+}
+
+// calcLost computes how many bytes of the segment weren't captured, by
+// comparing the IP total length against what was actually decoded.
+func calcLost(pckt *Packet) uint16 {
 	headerSize := int(uint32(pckt.DataOffset) + uint32(pckt.IHL()))
 	if pckt.Version() == 6 {
-		headerSize = int(pckt.DataOffset) // in ipv6 the length of payload doesn't include the IPheader size
+		// in ipv6 the length of payload doesn't include the fixed IP
+		// header, but it does include any extension headers ahead of
+		// the TCP header, so those have to be added back in here.
+		headerSize = int(pckt.DataOffset) + int(pckt.ExtensionHeadersLen())
 	}
-	pckt.Lost = pckt.Length() - uint16(headerSize+len(pckt.Payload))
-
-	return
+	return pckt.Length() - uint16(headerSize+len(pckt.Payload))
 }
 
 // Src format the source socket of a packet
@@ -135,7 +159,19 @@ func (pckt *Packet) Length() uint16 {
 	if l, ok := pckt.NetworkLayer.(*layers.IPv4); ok {
 		return l.Length
 	}
-	return pckt.NetworkLayer.(*layers.IPv6).Length
+	ip6 := pckt.NetworkLayer.(*layers.IPv6)
+	if ip6.Length == 0 {
+		// Length is only zero for a Jumbogram (RFC 2675); the real
+		// length lives in the Hop-by-Hop Jumbo Payload option. Note
+		// this still truncates to uint16 like the rest of this type's
+		// Length/Lost fields, so genuinely oversized (>64KiB) jumbograms
+		// won't round-trip exactly; that's an existing limit of Packet,
+		// not something this adds.
+		if jumbo, ok := jumbogramLength(ip6); ok {
+			return uint16(jumbo)
+		}
+	}
+	return ip6.Length
 }
 
 // SYNOptions returns MSS and windowscale of syn packets